@@ -0,0 +1,106 @@
+package echosentry
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Redactor strips or masks sensitive fields (e.g. password, token,
+// authorization) from a captured request body before it is sent to
+// Sentry, given the request's content type.
+type Redactor func(contentType string, body []byte) []byte
+
+// BodyCapture configures how, and whether, request bodies are captured
+// into Sentry events. It is opt-in: sending arbitrary request bodies to
+// Sentry can leak secrets and, read unconditionally, can OOM on large
+// uploads.
+type BodyCapture struct {
+	// Enabled turns body capture on.
+	Enabled bool
+
+	// MaxBytes caps how much of the body is read and sent. Defaults to
+	// 10KB.
+	MaxBytes int64
+
+	// ContentTypes lists the content-types allowed to be captured.
+	// Defaults to application/json and application/x-www-form-urlencoded.
+	ContentTypes []string
+
+	// Redactor, if set, is applied to the captured body before it's sent.
+	Redactor Redactor
+}
+
+// sensitiveHeaders have their values masked as "[Filtered]" in the captured
+// request before send, regardless of BodyCapture settings.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+var defaultBodyCapture = BodyCapture{
+	MaxBytes:     10 * 1024,
+	ContentTypes: []string{"application/json", "application/x-www-form-urlencoded"},
+}
+
+var bodyCapture = defaultBodyCapture
+
+// SetBodyCapture configures request body capture. Unset fields fall back
+// to the defaults (10KB, json/form content-types).
+func SetBodyCapture(cfg BodyCapture) {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultBodyCapture.MaxBytes
+	}
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = defaultBodyCapture.ContentTypes
+	}
+	bodyCapture = cfg
+}
+
+// captureBody reads up to bodyCapture.MaxBytes from r's body, if its
+// content-type is allowed, and returns the (possibly redacted) bytes.
+// r.Body is always restored so downstream handlers can still read it in
+// full, regardless of MaxBytes.
+func captureBody(r *http.Request) []byte {
+	if !bodyCapture.Enabled || r.Body == nil {
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if !contentTypeAllowed(contentType) {
+		return nil
+	}
+
+	captured, _ := ioutil.ReadAll(io.LimitReader(r.Body, bodyCapture.MaxBytes))
+	r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(captured), r.Body))
+
+	if len(captured) == 0 {
+		return nil
+	}
+
+	if bodyCapture.Redactor != nil {
+		captured = bodyCapture.Redactor(contentType, captured)
+	}
+
+	return captured
+}
+
+func contentTypeAllowed(contentType string) bool {
+	for _, allowed := range bodyCapture.ContentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubHeaders returns a copy of h in which the value of each present
+// sensitiveHeaders entry is replaced by "[Filtered]"; h itself is unchanged.
+func scrubHeaders(h http.Header) http.Header {
+	scrubbed := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, "[Filtered]")
+		}
+	}
+	return scrubbed
+}
@@ -0,0 +1,116 @@
+package echosentry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withBodyCapture(cfg BodyCapture, fn func()) {
+	prev := bodyCapture
+	defer func() { bodyCapture = prev }()
+	SetBodyCapture(cfg)
+	fn()
+}
+
+func TestCaptureBodyRestoresBodyForHandler(t *testing.T) {
+	withBodyCapture(BodyCapture{Enabled: true}, func() {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"a":1}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		captured := captureBody(req)
+		if string(captured) != `{"a":1}` {
+			t.Fatalf("captured = %q, want %q", captured, `{"a":1}`)
+		}
+
+		// The handler must still be able to read the full body.
+		remaining, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("reading restored body: %v", err)
+		}
+		if string(remaining) != `{"a":1}` {
+			t.Fatalf("restored body = %q, want %q", remaining, `{"a":1}`)
+		}
+	})
+}
+
+func TestCaptureBodyDisabledByDefault(t *testing.T) {
+	withBodyCapture(BodyCapture{}, func() {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"a":1}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		if got := captureBody(req); got != nil {
+			t.Fatalf("captureBody = %q, want nil when disabled", got)
+		}
+	})
+}
+
+func TestCaptureBodyRejectsDisallowedContentType(t *testing.T) {
+	withBodyCapture(BodyCapture{Enabled: true}, func() {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("binary junk"))
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		if got := captureBody(req); got != nil {
+			t.Fatalf("captureBody = %q, want nil for disallowed content-type", got)
+		}
+	})
+}
+
+func TestCaptureBodyRespectsMaxBytesButRestoresFullBody(t *testing.T) {
+	withBodyCapture(BodyCapture{Enabled: true, MaxBytes: 4}, func() {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("abcdefgh"))
+		req.Header.Set("Content-Type", "application/json")
+
+		captured := captureBody(req)
+		if string(captured) != "abcd" {
+			t.Fatalf("captured = %q, want %q", captured, "abcd")
+		}
+
+		remaining, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("reading restored body: %v", err)
+		}
+		if string(remaining) != "abcdefgh" {
+			t.Fatalf("restored body = %q, want full original %q", remaining, "abcdefgh")
+		}
+	})
+}
+
+func TestCaptureBodyAppliesRedactor(t *testing.T) {
+	redactor := func(contentType string, body []byte) []byte {
+		return []byte("[REDACTED]")
+	}
+
+	withBodyCapture(BodyCapture{Enabled: true, Redactor: redactor}, func() {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"password":"hunter2"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		if got := string(captureBody(req)); got != "[REDACTED]" {
+			t.Fatalf("captured = %q, want redacted", got)
+		}
+	})
+}
+
+func TestScrubHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer secret")
+	h.Set("Cookie", "session=abc")
+	h.Set("X-Request-ID", "req-1")
+
+	scrubbed := scrubHeaders(h)
+
+	if got := scrubbed.Get("Authorization"); got != "[Filtered]" {
+		t.Fatalf("Authorization = %q, want [Filtered]", got)
+	}
+	if got := scrubbed.Get("Cookie"); got != "[Filtered]" {
+		t.Fatalf("Cookie = %q, want [Filtered]", got)
+	}
+	if got := scrubbed.Get("X-Request-ID"); got != "req-1" {
+		t.Fatalf("X-Request-ID = %q, want untouched", got)
+	}
+	if h.Get("Authorization") != "Bearer secret" {
+		t.Fatalf("original header map was mutated")
+	}
+}
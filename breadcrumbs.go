@@ -0,0 +1,137 @@
+package echosentry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/labstack/echo"
+)
+
+// breadcrumbsContextKey is the key under which the request's breadcrumb
+// trail is stored via c.Set / c.Get.
+const breadcrumbsContextKey = "echosentry.breadcrumbs"
+
+// maxBreadcrumbs bounds how many breadcrumbs are kept per request; older
+// ones are dropped once the limit is reached.
+const maxBreadcrumbs = 100
+
+// breadcrumbTrail is the request-scoped, bounded list of breadcrumbs
+// accumulated before a panic or error is captured.
+type breadcrumbTrail struct {
+	mu   sync.Mutex
+	logs []*sentry.Breadcrumb
+}
+
+func (t *breadcrumbTrail) add(b *sentry.Breadcrumb) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.logs = append(t.logs, b)
+	if len(t.logs) > maxBreadcrumbs {
+		t.logs = t.logs[len(t.logs)-maxBreadcrumbs:]
+	}
+}
+
+func (t *breadcrumbTrail) drain() []*sentry.Breadcrumb {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.logs
+}
+
+// breadcrumbsFor returns the breadcrumb trail attached to c, creating and
+// storing one if this is the first breadcrumb of the request.
+func breadcrumbsFor(c echo.Context) *breadcrumbTrail {
+	if trail, ok := c.Get(breadcrumbsContextKey).(*breadcrumbTrail); ok {
+		return trail
+	}
+
+	trail := &breadcrumbTrail{}
+	c.Set(breadcrumbsContextKey, trail)
+	return trail
+}
+
+// AddBreadcrumb records a breadcrumb on c's request-scoped trail, to be
+// attached to the Sentry event if this request ends in a captured panic or
+// error. data may be nil.
+func AddBreadcrumb(c echo.Context, category, message string, data map[string]interface{}) {
+	breadcrumbsFor(c).add(&sentry.Breadcrumb{
+		Type:      "default",
+		Category:  category,
+		Message:   message,
+		Data:      data,
+		Level:     sentry.LevelInfo,
+		Timestamp: time.Now(),
+	})
+}
+
+// BreadcrumbLogger wraps an echo.Logger so that every Info/Warn/Debug/Error
+// call is also recorded as a breadcrumb on c's trail, in addition to being
+// written to the wrapped logger as usual.
+type BreadcrumbLogger struct {
+	echo.Logger
+	c echo.Context
+}
+
+// NewBreadcrumbLogger returns a BreadcrumbLogger wrapping logger, recording
+// breadcrumbs against c. Middleware installs one for every request
+// automatically (see withBreadcrumbLogger); call this directly only if
+// you need a BreadcrumbLogger outside of that, e.g. in a test.
+func NewBreadcrumbLogger(c echo.Context, logger echo.Logger) *BreadcrumbLogger {
+	return &BreadcrumbLogger{Logger: logger, c: c}
+}
+
+// breadcrumbContext wraps echo.Context so that Logger() returns a
+// BreadcrumbLogger scoped to this request. echo.Context.Logger() normally
+// returns the single Echo-instance-wide logger shared by every concurrent
+// request (see echo's context.go), so there's no per-request logger to
+// swap in-place without racing other in-flight requests; wrapping the
+// Context itself sidesteps that by giving each request its own Logger()
+// override instead of mutating shared state.
+type breadcrumbContext struct {
+	echo.Context
+	logger *BreadcrumbLogger
+}
+
+// Logger implements echo.Context, returning this request's BreadcrumbLogger.
+func (c *breadcrumbContext) Logger() echo.Logger {
+	return c.logger
+}
+
+// withBreadcrumbLogger wraps c so that handler calls to
+// c.Logger().Info/Warn/Debug/Error are also recorded as breadcrumbs.
+func withBreadcrumbLogger(c echo.Context) echo.Context {
+	bc := &breadcrumbContext{Context: c}
+	bc.logger = NewBreadcrumbLogger(c, c.Logger())
+	return bc
+}
+
+func (l *BreadcrumbLogger) record(level sentry.Level, args ...interface{}) {
+	AddBreadcrumb(l.c, "log", fmt.Sprint(args...), map[string]interface{}{"level": string(level)})
+}
+
+// Debug logs at debug level and records a breadcrumb.
+func (l *BreadcrumbLogger) Debug(i ...interface{}) {
+	l.record(sentry.LevelDebug, i...)
+	l.Logger.Debug(i...)
+}
+
+// Info logs at info level and records a breadcrumb.
+func (l *BreadcrumbLogger) Info(i ...interface{}) {
+	l.record(sentry.LevelInfo, i...)
+	l.Logger.Info(i...)
+}
+
+// Warn logs at warning level and records a breadcrumb.
+func (l *BreadcrumbLogger) Warn(i ...interface{}) {
+	l.record(sentry.LevelWarning, i...)
+	l.Logger.Warn(i...)
+}
+
+// Error logs at error level and records a breadcrumb.
+func (l *BreadcrumbLogger) Error(i ...interface{}) {
+	l.record(sentry.LevelError, i...)
+	l.Logger.Error(i...)
+}
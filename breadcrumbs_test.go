@@ -0,0 +1,88 @@
+package echosentry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/labstack/echo"
+)
+
+func newTestContext() echo.Context {
+	e := echo.New()
+	e.Logger.SetOutput(ioutil.Discard)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	return e.NewContext(req, httptest.NewRecorder())
+}
+
+func TestBreadcrumbTrailAddBoundsAt100(t *testing.T) {
+	trail := &breadcrumbTrail{}
+
+	for i := 0; i < maxBreadcrumbs+10; i++ {
+		trail.add(&sentry.Breadcrumb{Message: fmt.Sprintf("msg-%d", i)})
+	}
+
+	got := trail.drain()
+	if len(got) != maxBreadcrumbs {
+		t.Fatalf("len(drain()) = %d, want %d", len(got), maxBreadcrumbs)
+	}
+	if got[0].Message != "msg-10" {
+		t.Fatalf("oldest kept breadcrumb = %q, want %q (the first 10 should have been dropped)", got[0].Message, "msg-10")
+	}
+	if want := fmt.Sprintf("msg-%d", maxBreadcrumbs+9); got[len(got)-1].Message != want {
+		t.Fatalf("newest breadcrumb = %q, want %q", got[len(got)-1].Message, want)
+	}
+}
+
+func TestAddBreadcrumbDrain(t *testing.T) {
+	c := newTestContext()
+
+	AddBreadcrumb(c, "test", "hello", map[string]interface{}{"k": "v"})
+
+	got := breadcrumbsFor(c).drain()
+	if len(got) != 1 {
+		t.Fatalf("len(drain()) = %d, want 1", len(got))
+	}
+	if got[0].Category != "test" || got[0].Message != "hello" {
+		t.Fatalf("breadcrumb = %+v, want category=test message=hello", got[0])
+	}
+}
+
+func TestWithBreadcrumbLoggerRecordsLogCalls(t *testing.T) {
+	c := newTestContext()
+	wrapped := withBreadcrumbLogger(c)
+
+	wrapped.Logger().Info("hello world")
+
+	got := breadcrumbsFor(c).drain()
+	if len(got) != 1 {
+		t.Fatalf("len(drain()) = %d, want 1", len(got))
+	}
+	if got[0].Message != "hello world" {
+		t.Fatalf("Message = %q, want %q", got[0].Message, "hello world")
+	}
+	if got[0].Data["level"] != string(sentry.LevelInfo) {
+		t.Fatalf("Data[level] = %v, want %q", got[0].Data["level"], sentry.LevelInfo)
+	}
+}
+
+func TestWithBreadcrumbLoggerIsRequestScoped(t *testing.T) {
+	c1 := newTestContext()
+	c2 := newTestContext()
+
+	withBreadcrumbLogger(c1).Logger().Info("from request 1")
+	withBreadcrumbLogger(c2).Logger().Warn("from request 2")
+
+	got1 := breadcrumbsFor(c1).drain()
+	got2 := breadcrumbsFor(c2).drain()
+
+	if len(got1) != 1 || got1[0].Message != "from request 1" {
+		t.Fatalf("request 1 trail = %+v, want a single 'from request 1' breadcrumb", got1)
+	}
+	if len(got2) != 1 || got2[0].Message != "from request 2" {
+		t.Fatalf("request 2 trail = %+v, want a single 'from request 2' breadcrumb", got2)
+	}
+}
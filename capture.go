@@ -3,43 +3,66 @@ package echosentry
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"net/http"
 	"runtime/debug"
 
-	"github.com/getsentry/raven-go"
+	"github.com/getsentry/sentry-go"
 	"github.com/labstack/echo"
 )
 
-// Sentry struct holding the raven client and some of its configs
+// Sentry struct holding the sentry-go client's configuration.
 type Sentry struct {
 	withContext bool
-	RavenClient *raven.Client
-	Tags        map[string]string
 }
 
 // TagsFunc given a request context, extract some additional tags and return
-// them as map[string]string as required by the raven client.
+// them as map[string]string to be attached to the scope of the captured event.
 type TagsFunc func(c echo.Context) map[string]string
 
+// ShouldReportFunc decides whether a non-nil error returned by a handler
+// should be captured. The default skips *echo.HTTPError with a 4xx status,
+// since those are normal client-facing responses rather than failures.
+type ShouldReportFunc func(err error, c echo.Context) bool
+
 var (
-	sentry   = &Sentry{}
+	instance = &Sentry{}
 	tagsFunc TagsFunc
+
+	shouldReportFunc ShouldReportFunc = defaultShouldReport
 )
 
-// SetDSN creates a raven client and sets its Sentry server DSN.
+// SetDSN initializes the sentry-go client with the given DSN. For anything
+// beyond the DSN (SampleRate, Environment, Release, TracesSampleRate,
+// BeforeSend, Transport, ...), use SetOptions instead.
 func SetDSN(dsn string) {
-	client, err := raven.New(dsn)
-	if err != nil {
+	SetOptions(sentry.ClientOptions{Dsn: dsn})
+}
+
+// SetOptions initializes the sentry-go client with opts. This replaces the
+// raven-go client with sentry.Init, exposing everything
+// sentry.ClientOptions supports. All options are passed through unchanged
+// except BeforeSend, which is wrapped so that source context (see
+// SetSourceLoader) is filled in before opts.BeforeSend, if set, runs.
+func SetOptions(opts sentry.ClientOptions) {
+	userBeforeSend := opts.BeforeSend
+	opts.BeforeSend = func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		enrichSourceContext(event)
+		if userBeforeSend != nil {
+			event = userBeforeSend(event, hint)
+		}
+		return event
+	}
+
+	if err := sentry.Init(opts); err != nil {
 		log.Fatal(err)
 	}
-	sentry.RavenClient = client
 }
 
 // WithContext sets weather or not the HTTP context is sent with the log.
 // This adds info about the user's browser, URL, OS, device, interface_type ..etc
 func WithContext(yepnope bool) {
-	sentry.withContext = yepnope
+	instance.withContext = yepnope
 }
 
 // Sets any other additional tags to be captured by Sentry.
@@ -49,12 +72,42 @@ func SetTags(fn TagsFunc) {
 	tagsFunc = fn
 }
 
+// SetShouldReport overrides which handler-returned errors get captured.
+// By default, *echo.HTTPError with a 4xx status is not reported.
+func SetShouldReport(fn ShouldReportFunc) {
+	shouldReportFunc = fn
+}
+
+func defaultShouldReport(err error, c echo.Context) bool {
+	var he *echo.HTTPError
+	if errors.As(err, &he) && he.Code >= 400 && he.Code < http.StatusInternalServerError {
+		return false
+	}
+	return true
+}
+
 // Middleware returns an echo middleware which recovers from panics anywhere in the chain
 // and logs to the sentry server specified in DSN.
 func Middleware() echo.MiddlewareFunc {
 
 	return func(h echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
+		return func(c echo.Context) (handlerErr error) {
+			hub := sentry.CurrentHub().Clone()
+			c.Set(hubContextKey, hub)
+			c = withBreadcrumbLogger(c)
+
+			hub.ConfigureScope(func(scope *sentry.Scope) {
+				scope.SetTag("request_id", requestID(c.Request()))
+				scope.SetTag("route", c.Path())
+				scope.SetTag("method", c.Request().Method)
+				scope.SetExtra("remote_ip", c.RealIP())
+			})
+
+			// Read and restore the body before the handler runs. Capturing
+			// it afterwards is too late: handlers commonly drain the body
+			// themselves (c.Bind, etc.) before erroring or panicking.
+			bodyBytes := captureBody(c.Request())
+
 			defer func() {
 				if rval := recover(); rval != nil {
 					debug.PrintStack()
@@ -62,47 +115,87 @@ func Middleware() echo.MiddlewareFunc {
 					errorMsg := fmt.Sprint(rval)
 					err := errors.New(errorMsg)
 
-					stacktrace := raven.NewException(err, raven.NewStacktrace(2, 3, nil))
+					capture(c, hub, err, bodyBytes)
 
-					httpContext := &raven.Http{}
+					// register the error back to echo.Context
+					c.Error(err)
+				}
+			}()
 
-					if sentry.withContext {
-						httpContext = raven.NewHttp(c.Request())
-					}
+			handlerErr = h(c)
+			if handlerErr != nil && shouldReportFunc(handlerErr, c) {
+				capture(c, hub, handlerErr, bodyBytes)
+			}
 
-					// extract tags
-					if tagsFunc != nil {
-						sentry.Tags = tagsFunc(c)
-					}
+			return handlerErr
+		}
+	}
+}
 
-					// extract body
-					var bodyBytes []byte
-					if c.Request().Body != nil {
-						bodyBytes, _ = ioutil.ReadAll(c.Request().Body)
-					}
+// capture snapshots everything it needs from c - HTTP context, tags,
+// breadcrumbs, status, and the unwrapped error chain - and schedules the
+// actual send to Sentry on the async capture pipeline, so a Sentry outage
+// or slow network doesn't block the request goroutine. bodyBytes is the
+// body captured (if any) before the handler ran. The snapshot happens
+// synchronously because c and its request are recycled by echo once the
+// handler returns.
+func capture(c echo.Context, hub *sentry.Hub, err error, bodyBytes []byte) {
+	if !sampled() {
+		return
+	}
 
-					// contruct the raven packet to be sent
-					var packet *raven.Packet
-					if len(bodyBytes) > 0 {
-						packet = raven.NewPacketWithExtra(errorMsg, raven.Extra{"requestBody": string(bodyBytes)}, stacktrace, httpContext)
-					} else {
-						packet = raven.NewPacket(errorMsg, stacktrace, httpContext)
-					}
+	var scrubbedRequest *http.Request
+	if instance.withContext {
+		scrubbedRequest = c.Request().Clone(c.Request().Context())
+		scrubbedRequest.Header = scrubHeaders(c.Request().Header)
+	}
 
-					// capture it and send.
-					sentry.RavenClient.Capture(packet, sentry.Tags)
+	var tags map[string]string
+	if tagsFunc != nil {
+		tags = tagsFunc(c)
+	}
 
-					// register the error back to echo.Context
-					c.Error(err)
-				}
-			}()
+	status := http.StatusInternalServerError
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status = he.Code
+	}
 
-			return h(c)
-		}
+	// record the unwrapped error chain so returned-error flow is visible
+	// without needing a panic to get a stacktrace.
+	var chain []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
 	}
+
+	breadcrumbs := breadcrumbsFor(c).drain()
+
+	enqueue(func() {
+		hub.WithScope(func(scope *sentry.Scope) {
+			if scrubbedRequest != nil {
+				scope.SetRequest(scrubbedRequest)
+			}
+
+			scope.SetTags(tags)
+			scope.SetTag("status", fmt.Sprint(status))
+
+			if len(bodyBytes) > 0 {
+				scope.SetExtra("requestBody", string(bodyBytes))
+			}
+			if len(chain) > 1 {
+				scope.SetExtra("errorChain", chain)
+			}
+
+			for _, b := range breadcrumbs {
+				scope.AddBreadcrumb(b, maxBreadcrumbs)
+			}
+
+			hub.CaptureException(err)
+		})
+	})
 }
 
 func init() {
 	// HTTP context enabled by default for convenience
-	sentry.withContext = true
+	instance.withContext = true
 }
@@ -0,0 +1,31 @@
+package echosentry
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestDefaultShouldReportSkips4xxHTTPError(t *testing.T) {
+	err := echo.NewHTTPError(http.StatusBadRequest, "bad request")
+
+	if defaultShouldReport(err, nil) {
+		t.Fatal("defaultShouldReport = true, want false for a 4xx *echo.HTTPError")
+	}
+}
+
+func TestDefaultShouldReportReports5xxHTTPError(t *testing.T) {
+	err := echo.NewHTTPError(http.StatusInternalServerError, "boom")
+
+	if !defaultShouldReport(err, nil) {
+		t.Fatal("defaultShouldReport = false, want true for a 5xx *echo.HTTPError")
+	}
+}
+
+func TestDefaultShouldReportReportsPlainError(t *testing.T) {
+	if !defaultShouldReport(errors.New("boom"), nil) {
+		t.Fatal("defaultShouldReport = false, want true for a plain (non-HTTPError) error")
+	}
+}
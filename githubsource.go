@@ -0,0 +1,166 @@
+package echosentry
+
+import (
+	"container/list"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// githubFetchTimeout bounds how long a single raw.githubusercontent.com
+// fetch may take. fetch() runs synchronously inside a capture pipeline
+// worker (via ClientOptions.BeforeSend), so an unbounded client would let
+// a slow or hanging network stall that worker - and, under the default
+// DropPolicyBlock with a single worker, the whole queue - indefinitely.
+const githubFetchTimeout = 5 * time.Second
+
+// GitHubSourceLoader loads source context from raw.githubusercontent.com.
+// Repos maps a filename prefix (typically a module root) to the
+// "owner/repo@commit" it should be fetched from, so stack frames with
+// local build paths resolve to the right file in the right revision.
+type GitHubSourceLoader struct {
+	Repos      map[string]string
+	HTTPClient *http.Client
+
+	cache *sourceCache
+}
+
+// NewGitHubSourceLoader returns a GitHubSourceLoader backed by an
+// in-memory LRU cache of the last cacheSize files fetched (128 if
+// cacheSize <= 0).
+func NewGitHubSourceLoader(repos map[string]string, cacheSize int) *GitHubSourceLoader {
+	return &GitHubSourceLoader{
+		Repos:      repos,
+		HTTPClient: &http.Client{Timeout: githubFetchTimeout},
+		cache:      newSourceCache(cacheSize),
+	}
+}
+
+// Load implements SourceLoader.
+func (l *GitHubSourceLoader) Load(filename string, line, contextLines int) (pre, cur, post []string, err error) {
+	url, ok := l.resolve(filename)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("echosentry: no GitHub source mapping for %s", filename)
+	}
+
+	lines, err := l.fetch(url)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return sliceContext(lines, line, contextLines)
+}
+
+// resolve maps filename to its raw.githubusercontent.com URL via the
+// longest matching root in l.Repos, using pathUnderRoot so a sibling path
+// that merely shares a string prefix with a root isn't mapped into it.
+func (l *GitHubSourceLoader) resolve(filename string) (url string, ok bool) {
+	var bestRoot string
+	var bestRepoCommit string
+
+	for root, repoCommit := range l.Repos {
+		root := filepath.Clean(root)
+		if pathUnderRoot(filename, root) && len(root) > len(bestRoot) {
+			bestRoot, bestRepoCommit = root, repoCommit
+		}
+	}
+	if bestRoot == "" {
+		return "", false
+	}
+
+	repo, commit := bestRepoCommit, "HEAD"
+	if i := strings.LastIndex(bestRepoCommit, "@"); i >= 0 {
+		repo, commit = bestRepoCommit[:i], bestRepoCommit[i+1:]
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(filepath.Clean(filename), bestRoot), string(filepath.Separator))
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repo, commit, rel), true
+}
+
+func (l *GitHubSourceLoader) fetch(url string) ([]string, error) {
+	if lines, ok := l.cache.get(url); ok {
+		return lines, nil
+	}
+
+	resp, err := l.HTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("echosentry: fetching %s: %s", url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(body), "\n")
+	l.cache.put(url, lines)
+	return lines, nil
+}
+
+// sourceCache is a small, bounded, in-memory LRU cache of fetched file
+// contents, keyed by URL.
+type sourceCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type sourceCacheEntry struct {
+	key   string
+	lines []string
+}
+
+func newSourceCache(capacity int) *sourceCache {
+	if capacity <= 0 {
+		capacity = 128
+	}
+	return &sourceCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *sourceCache) get(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*sourceCacheEntry).lines, true
+}
+
+func (c *sourceCache) put(key string, lines []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*sourceCacheEntry).lines = lines
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&sourceCacheEntry{key: key, lines: lines})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*sourceCacheEntry).key)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package echosentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewGitHubSourceLoaderHasFetchTimeout(t *testing.T) {
+	l := NewGitHubSourceLoader(nil, 0)
+
+	if l.HTTPClient.Timeout <= 0 {
+		t.Fatal("default HTTPClient has no timeout; a hanging fetch would stall a capture worker indefinitely")
+	}
+}
+
+func TestGitHubSourceLoaderResolve(t *testing.T) {
+	l := NewGitHubSourceLoader(map[string]string{
+		"/go/src/app": "acme/app@abc123",
+	}, 0)
+
+	url, ok := l.resolve("/go/src/app/pkg/handler.go")
+	if !ok {
+		t.Fatal("resolve() = false, want true for a file under a mapped root")
+	}
+	want := "https://raw.githubusercontent.com/acme/app/abc123/pkg/handler.go"
+	if url != want {
+		t.Fatalf("resolve() = %q, want %q", url, want)
+	}
+
+	if _, ok := l.resolve("/unmapped/path/x.go"); ok {
+		t.Fatal("resolve() = true for a file outside any mapped root")
+	}
+}
+
+func TestGitHubSourceLoaderResolveRejectsSiblingDirectory(t *testing.T) {
+	l := NewGitHubSourceLoader(map[string]string{
+		"/go/src/app": "acme/app@abc123",
+	}, 0)
+
+	if _, ok := l.resolve("/go/src/app-other/secret.go"); ok {
+		t.Fatal("sibling directory sharing a string prefix with the root was resolved")
+	}
+}
+
+func TestGitHubSourceLoaderFetchCaches(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("line1\nline2\nline3\n"))
+	}))
+	defer srv.Close()
+
+	l := NewGitHubSourceLoader(nil, 0)
+
+	lines, err := l.fetch(srv.URL)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(lines) != 4 { // trailing empty string after the last \n
+		t.Fatalf("lines = %v, want 4 elements", lines)
+	}
+
+	if _, err := l.fetch(srv.URL); err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("server received %d requests, want 1 (second fetch should hit the cache)", requests)
+	}
+}
+
+func TestGitHubSourceLoaderFetchNon200(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	l := NewGitHubSourceLoader(nil, 0)
+
+	if _, err := l.fetch(srv.URL); err == nil {
+		t.Fatal("fetch() returned no error for a 404 response")
+	}
+}
+
+func TestSourceCacheEvictsOldest(t *testing.T) {
+	c := newSourceCache(2)
+
+	c.put("a", []string{"a"})
+	c.put("b", []string{"b"})
+	c.put("c", []string{"c"}) // evicts "a"
+
+	if _, ok := c.get("a"); ok {
+		t.Fatal("oldest entry was not evicted")
+	}
+	if _, ok := c.get("b"); !ok {
+		t.Fatal("entry b should still be cached")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Fatal("entry c should be cached")
+	}
+}
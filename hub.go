@@ -0,0 +1,43 @@
+package echosentry
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/labstack/echo"
+)
+
+// hubContextKey is the key under which the request's cloned hub is stored
+// via c.Set / c.Get.
+const hubContextKey = "echosentry.hub"
+
+// requestIDHeader is read for an inbound request ID; one is generated if
+// absent.
+const requestIDHeader = "X-Request-ID"
+
+// Hub returns the Sentry hub scoped to c's request, as installed by
+// Middleware. Handlers can use it to attach request-specific context -
+// SetUser, SetExtra, dynamic tags - without racing other concurrent
+// requests, since each request gets its own cloned hub.
+func Hub(c echo.Context) *sentry.Hub {
+	if hub, ok := c.Get(hubContextKey).(*sentry.Hub); ok {
+		return hub
+	}
+	return sentry.CurrentHub()
+}
+
+// requestID returns the inbound X-Request-ID header, or a freshly
+// generated one if the request didn't carry one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,30 @@
+package echosentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDUsesHeaderWhenPresent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestIDHeader, "abc-123")
+
+	if got := requestID(req); got != "abc-123" {
+		t.Fatalf("requestID() = %q, want %q", got, "abc-123")
+	}
+}
+
+func TestRequestIDGeneratesWhenHeaderAbsent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	id1 := requestID(req)
+	id2 := requestID(req)
+
+	if id1 == "" {
+		t.Fatal("requestID() returned an empty string")
+	}
+	if id1 == id2 {
+		t.Fatal("requestID() generated the same id twice")
+	}
+}
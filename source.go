@@ -0,0 +1,131 @@
+package echosentry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SourceLoader loads the source lines surrounding a stack frame, following
+// the pattern used by stcrashreceiver's SetSourceCodeLoader. cur holds the
+// line itself (as a single-element slice, for symmetry with pre/post).
+type SourceLoader interface {
+	Load(filename string, line, contextLines int) (pre, cur, post []string, err error)
+}
+
+// sourceContextLines is how many lines of context are loaded around each
+// frame.
+const sourceContextLines = 5
+
+var sourceLoader SourceLoader
+
+// SetSourceLoader wires loader into the outgoing event, so each stack
+// frame includes surrounding source lines. This makes Sentry issues
+// immediately actionable without needing release artifacts uploaded.
+func SetSourceLoader(loader SourceLoader) {
+	sourceLoader = loader
+}
+
+// enrichSourceContext fills in PreContext/ContextLine/PostContext on every
+// exception frame of event using sourceLoader, if one is configured.
+func enrichSourceContext(event *sentry.Event) {
+	if sourceLoader == nil {
+		return
+	}
+
+	for _, ex := range event.Exception {
+		if ex.Stacktrace == nil {
+			continue
+		}
+
+		for i := range ex.Stacktrace.Frames {
+			frame := &ex.Stacktrace.Frames[i]
+			if frame.Filename == "" || frame.Lineno <= 0 {
+				continue
+			}
+
+			pre, cur, post, err := sourceLoader.Load(frame.Filename, frame.Lineno, sourceContextLines)
+			if err != nil {
+				continue
+			}
+
+			frame.PreContext = pre
+			if len(cur) > 0 {
+				frame.ContextLine = cur[0]
+			}
+			frame.PostContext = post
+		}
+	}
+}
+
+// pathUnderRoot reports whether filename falls under root. Both are
+// filepath.Clean-ed first, so ".." segments are resolved away rather than
+// matched textually, and a full path-segment match is required so a
+// sibling path that merely shares a string prefix with root (e.g. root
+// "/srv/app/src" and filename "/srv/app/src-secrets/x.go") isn't treated
+// as contained in it. Shared by LocalSourceLoader and GitHubSourceLoader.
+func pathUnderRoot(filename, root string) bool {
+	filename = filepath.Clean(filename)
+	root = filepath.Clean(root)
+	return filename == root || strings.HasPrefix(filename, root+string(filepath.Separator))
+}
+
+// sliceContext splits lines (1-indexed by line) into the contextLines
+// before line, line itself, and the contextLines after.
+func sliceContext(lines []string, line, contextLines int) (pre, cur, post []string, err error) {
+	idx := line - 1
+	if idx < 0 || idx >= len(lines) {
+		return nil, nil, nil, fmt.Errorf("echosentry: line %d out of range (file has %d lines)", line, len(lines))
+	}
+
+	start := idx - contextLines
+	if start < 0 {
+		start = 0
+	}
+	end := idx + contextLines + 1
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	return lines[start:idx], lines[idx : idx+1], lines[idx+1 : end], nil
+}
+
+// LocalSourceLoader loads source from the local filesystem, restricted to
+// an allowlist of module roots so a crafted filename can't leak arbitrary
+// files off disk.
+type LocalSourceLoader struct {
+	Roots []string
+}
+
+// NewLocalSourceLoader returns a LocalSourceLoader restricted to roots.
+func NewLocalSourceLoader(roots ...string) *LocalSourceLoader {
+	return &LocalSourceLoader{Roots: roots}
+}
+
+// Load implements SourceLoader.
+func (l *LocalSourceLoader) Load(filename string, line, contextLines int) (pre, cur, post []string, err error) {
+	clean := filepath.Clean(filename)
+	if !l.allowed(clean) {
+		return nil, nil, nil, fmt.Errorf("echosentry: %s is outside the configured source roots", filename)
+	}
+
+	data, err := ioutil.ReadFile(clean)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return sliceContext(strings.Split(string(data), "\n"), line, contextLines)
+}
+
+// allowed reports whether filename falls under one of l.Roots.
+func (l *LocalSourceLoader) allowed(filename string) bool {
+	for _, root := range l.Roots {
+		if pathUnderRoot(filename, root) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package echosentry
+
+import "testing"
+
+func TestLocalSourceLoaderAllowedRejectsSiblingDirectory(t *testing.T) {
+	l := NewLocalSourceLoader("/srv/app/src")
+
+	if l.allowed("/srv/app/src-secrets/dbpassword.go") {
+		t.Fatal("sibling directory sharing a string prefix with the root was allowed")
+	}
+}
+
+func TestLocalSourceLoaderAllowedRejectsPathTraversal(t *testing.T) {
+	l := NewLocalSourceLoader("/srv/app/src")
+
+	if l.allowed("/srv/app/src/../../etc/passwd") {
+		t.Fatal("path traversal escaping the root was allowed")
+	}
+}
+
+func TestLocalSourceLoaderAllowedAcceptsFileUnderRoot(t *testing.T) {
+	l := NewLocalSourceLoader("/srv/app/src")
+
+	if !l.allowed("/srv/app/src/pkg/handler.go") {
+		t.Fatal("file under the root was rejected")
+	}
+	if !l.allowed("/srv/app/src") {
+		t.Fatal("the root itself was rejected")
+	}
+}
+
+func TestSliceContext(t *testing.T) {
+	lines := []string{"a", "b", "c", "d", "e"}
+
+	pre, cur, post, err := sliceContext(lines, 3, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pre) != 1 || pre[0] != "b" {
+		t.Fatalf("pre = %v, want [b]", pre)
+	}
+	if len(cur) != 1 || cur[0] != "c" {
+		t.Fatalf("cur = %v, want [c]", cur)
+	}
+	if len(post) != 1 || post[0] != "d" {
+		t.Fatalf("post = %v, want [d]", post)
+	}
+
+	if _, _, _, err := sliceContext(lines, 99, 1); err == nil {
+		t.Fatal("expected an error for an out-of-range line")
+	}
+}
@@ -0,0 +1,155 @@
+package echosentry
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy controls what happens to a captured event when the async
+// queue is full.
+type DropPolicy int
+
+const (
+	// DropPolicyBlock blocks the caller until the queue has room. This is
+	// the default, and is the safest choice when captures must not be
+	// lost, at the cost of potentially stalling a request goroutine.
+	DropPolicyBlock DropPolicy = iota
+	// DropPolicyDropOldest discards the oldest queued event to make room
+	// for the new one.
+	DropPolicyDropOldest
+	// DropPolicyDropNewest discards the incoming event, keeping the
+	// queue as-is.
+	DropPolicyDropNewest
+)
+
+// AsyncCapture configures the background worker pool that ships captured
+// events to Sentry off the request goroutine, so a Sentry outage or slow
+// network doesn't block echo handlers.
+type AsyncCapture struct {
+	// QueueSize bounds how many captured events may be buffered. Defaults
+	// to 100.
+	QueueSize int
+
+	// Workers is how many goroutines drain the queue concurrently.
+	// Defaults to 1.
+	Workers int
+
+	// DropPolicy decides what happens when the queue is full. Defaults
+	// to DropPolicyBlock.
+	DropPolicy DropPolicy
+
+	// SampleRate is the client-side sampling rate (0..1) applied to
+	// captured panics/errors before they're queued: 0 captures nothing, 1
+	// captures everything. A nil SampleRate defaults to 1 (capture
+	// everything); it's a pointer rather than a plain float64 so that an
+	// explicit 0 ("capture nothing") is distinguishable from "not set".
+	SampleRate *float64
+}
+
+var (
+	defaultSampleRate   = 1.0
+	defaultAsyncCapture = AsyncCapture{QueueSize: 100, Workers: 1, SampleRate: &defaultSampleRate}
+	asyncCapture        = defaultAsyncCapture
+
+	jobs         chan func()
+	workersOnce  sync.Once
+	workersWG    sync.WaitGroup
+	pending      int64
+	droppedCount uint64
+)
+
+// SetAsyncCapture configures the capture pipeline. Call it before the
+// first request flows through Middleware, since the worker pool is sized
+// and started lazily on first use.
+func SetAsyncCapture(cfg AsyncCapture) {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultAsyncCapture.QueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultAsyncCapture.Workers
+	}
+	if cfg.SampleRate == nil {
+		cfg.SampleRate = &defaultSampleRate
+	}
+	asyncCapture = cfg
+}
+
+// DroppedEvents returns how many captured events have been discarded
+// under DropPolicyDropOldest/DropPolicyDropNewest.
+func DroppedEvents() uint64 {
+	return atomic.LoadUint64(&droppedCount)
+}
+
+func startWorkers() {
+	workersOnce.Do(func() {
+		jobs = make(chan func(), asyncCapture.QueueSize)
+		for i := 0; i < asyncCapture.Workers; i++ {
+			workersWG.Add(1)
+			go worker()
+		}
+	})
+}
+
+func worker() {
+	defer workersWG.Done()
+	for job := range jobs {
+		job()
+		atomic.AddInt64(&pending, -1)
+	}
+}
+
+// sampled reports whether an event passing through the sampler should be
+// kept, per asyncCapture.SampleRate.
+func sampled() bool {
+	rate := *asyncCapture.SampleRate
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// enqueue schedules job to run on a worker goroutine, applying
+// asyncCapture.DropPolicy if the queue is full.
+func enqueue(job func()) {
+	startWorkers()
+	atomic.AddInt64(&pending, 1)
+
+	select {
+	case jobs <- job:
+		return
+	default:
+	}
+
+	switch asyncCapture.DropPolicy {
+	case DropPolicyDropNewest:
+		atomic.AddInt64(&pending, -1)
+		atomic.AddUint64(&droppedCount, 1)
+	case DropPolicyDropOldest:
+		select {
+		case <-jobs:
+			atomic.AddInt64(&pending, -1)
+			atomic.AddUint64(&droppedCount, 1)
+		default:
+		}
+		select {
+		case jobs <- job:
+		default:
+			atomic.AddInt64(&pending, -1)
+			atomic.AddUint64(&droppedCount, 1)
+		}
+	default: // DropPolicyBlock
+		jobs <- job
+	}
+}
+
+// Flush blocks until the capture queue has drained or timeout elapses,
+// returning whether it drained in time. Call it on shutdown.
+func Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&pending) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
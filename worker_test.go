@@ -0,0 +1,121 @@
+package echosentry
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func withAsyncCaptureConfig(cfg AsyncCapture, fn func()) {
+	prev := asyncCapture
+	defer func() { asyncCapture = prev }()
+	rate := cfg.SampleRate
+	if rate == nil {
+		rate = &defaultSampleRate
+	}
+	asyncCapture = AsyncCapture{QueueSize: cfg.QueueSize, Workers: cfg.Workers, DropPolicy: cfg.DropPolicy, SampleRate: rate}
+	fn()
+}
+
+func TestSampledZeroRateNeverSamples(t *testing.T) {
+	zero := 0.0
+	withAsyncCaptureConfig(AsyncCapture{SampleRate: &zero}, func() {
+		for i := 0; i < 50; i++ {
+			if sampled() {
+				t.Fatal("sampled() returned true with SampleRate 0")
+			}
+		}
+	})
+}
+
+func TestSampledFullRateAlwaysSamples(t *testing.T) {
+	one := 1.0
+	withAsyncCaptureConfig(AsyncCapture{SampleRate: &one}, func() {
+		for i := 0; i < 50; i++ {
+			if !sampled() {
+				t.Fatal("sampled() returned false with SampleRate 1")
+			}
+		}
+	})
+}
+
+func TestSetAsyncCaptureDefaultsNilSampleRateToOne(t *testing.T) {
+	prev := asyncCapture
+	defer func() { asyncCapture = prev }()
+
+	SetAsyncCapture(AsyncCapture{})
+	if got := *asyncCapture.SampleRate; got != 1 {
+		t.Fatalf("SampleRate = %v, want 1", got)
+	}
+}
+
+func TestSetAsyncCapturePreservesExplicitZeroSampleRate(t *testing.T) {
+	prev := asyncCapture
+	defer func() { asyncCapture = prev }()
+
+	zero := 0.0
+	SetAsyncCapture(AsyncCapture{SampleRate: &zero})
+	if got := *asyncCapture.SampleRate; got != 0 {
+		t.Fatalf("SampleRate = %v, want 0 (explicit zero must not be forced to the default)", got)
+	}
+}
+
+// TestEnqueueDropPolicyDropNewestWhenFull and
+// TestEnqueueDropPolicyDropOldestWhenFull exercise the real package-level
+// worker pool, which is started exactly once (on the first enqueue call in
+// the whole test binary) and keeps whatever QueueSize/Workers it started
+// with. They must therefore be the first tests in this package to call
+// enqueue, and they share the pool they start.
+func TestEnqueueDropPolicyDropNewestWhenFull(t *testing.T) {
+	SetAsyncCapture(AsyncCapture{QueueSize: 1, Workers: 1, DropPolicy: DropPolicyDropNewest})
+
+	blocking := make(chan struct{})
+	workerStarted := make(chan struct{})
+	enqueue(func() {
+		close(workerStarted)
+		<-blocking
+	})
+	<-workerStarted // the lone worker is now blocked on this job
+
+	before := DroppedEvents()
+
+	enqueue(func() {}) // fills the one-slot queue
+	enqueue(func() {}) // queue is full; must be dropped under DropPolicyDropNewest
+
+	if got := DroppedEvents(); got != before+1 {
+		t.Fatalf("DroppedEvents() = %d, want %d", got, before+1)
+	}
+
+	close(blocking)
+	if !Flush(2 * time.Second) {
+		t.Fatal("Flush did not drain in time")
+	}
+}
+
+func TestEnqueueDropPolicyDropOldestWhenFull(t *testing.T) {
+	SetAsyncCapture(AsyncCapture{QueueSize: 1, Workers: 1, DropPolicy: DropPolicyDropOldest})
+
+	blocking := make(chan struct{})
+	workerStarted := make(chan struct{})
+	enqueue(func() {
+		close(workerStarted)
+		<-blocking
+	})
+	<-workerStarted
+
+	var oldestRan, newestRan int32
+	enqueue(func() { atomic.AddInt32(&oldestRan, 1) }) // fills the one-slot queue
+	enqueue(func() { atomic.AddInt32(&newestRan, 1) }) // should evict the oldest queued job
+
+	close(blocking)
+	if !Flush(2 * time.Second) {
+		t.Fatal("Flush did not drain in time")
+	}
+
+	if atomic.LoadInt32(&oldestRan) != 0 {
+		t.Fatal("oldest queued job ran, want it dropped to make room")
+	}
+	if atomic.LoadInt32(&newestRan) != 1 {
+		t.Fatal("newest job did not run")
+	}
+}